@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Backend is the interface a secret store driver must implement so that
+// secretly can load secrets from SSM, Secrets Manager, Vault, Azure Key
+// Vault, or any future store behind the same --backend flag.
+type Backend interface {
+	// List returns every secret found under namespace, keyed by the env var
+	// name the backend derives for it. That is usually the leaf name (the
+	// portion of the secret's path/name after namespace), but a backend may
+	// expand one secret into several entries: asm keys a JSON secret by its
+	// field names, and vault keys each field as <NAME>_<field>.
+	List(ctx context.Context, namespace string) (map[string]string, error)
+}
+
+// backendName resolves the backend to use from the --backend flag, falling
+// back to the SECRETLY_BACKEND environment variable and finally "ssm" so
+// existing callers keep working unchanged.
+func backendName(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv("SECRETLY_BACKEND"); env != "" {
+		return env
+	}
+	return "ssm"
+}
+
+// backendFactory constructs the Backend for a given name. It's a variable,
+// rather than calling newBackend directly, so tests (e.g. for config.go)
+// can substitute a fake without needing real cloud credentials.
+var backendFactory = newBackend
+
+// newBackend constructs the Backend registered under name. Backends are
+// ssm, asm, vault, and azkv; a GCP Secret Manager driver ("gcpsm") is out of
+// scope for this set and isn't registered here, so --backend=gcpsm fails
+// like any other unrecognized name until one is added behind the same
+// Backend interface.
+func newBackend(name string) (Backend, error) {
+	switch strings.ToLower(name) {
+	case "ssm":
+		return newSSMBackend()
+	case "asm":
+		return newASMBackend()
+	case "vault":
+		return newVaultBackend()
+	case "azkv":
+		return newAzureKeyVaultBackend()
+	default:
+		return nil, fmt.Errorf("secretly: unknown backend %q (want ssm, asm, vault, or azkv)", name)
+	}
+}
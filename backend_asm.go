@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+// asmBackend loads secrets from AWS Secrets Manager.
+type asmBackend struct {
+	client secretsmanageriface.SecretsManagerAPI
+}
+
+func newASMBackend() (Backend, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &asmBackend{client: secretsmanager.New(sess)}, nil
+}
+
+// List returns every secret whose name is under namespace. Secrets that
+// hold a flat JSON object are expanded into one entry per field instead of
+// one entry holding the raw JSON blob.
+func (b *asmBackend) List(ctx context.Context, namespace string) (map[string]string, error) {
+	path := "/" + strings.Trim(namespace, "/") + "/"
+	secrets := map[string]string{}
+
+	input := &secretsmanager.ListSecretsInput{
+		Filters: []*secretsmanager.Filter{
+			{Key: aws.String("name"), Values: []*string{aws.String(path)}},
+		},
+	}
+	for {
+		out, err := b.client.ListSecretsWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range out.SecretList {
+			value, err := b.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+				SecretId: entry.ARN,
+			})
+			if err != nil {
+				return nil, err
+			}
+			key := strings.TrimPrefix(aws.StringValue(entry.Name), path)
+			for k, v := range expandSecretValue(key, aws.StringValue(value.SecretString)) {
+				secrets[k] = v
+			}
+		}
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	return secrets, nil
+}
+
+// expandSecretValue turns a secret's raw string value into one or more env
+// var entries. If value parses as a flat JSON object, its fields become
+// individual entries keyed by their JSON field names; otherwise the whole
+// value is kept under key.
+func expandSecretValue(key, value string) map[string]string {
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(value), &fields); err == nil && len(fields) > 0 {
+		return fields
+	}
+	return map[string]string{key: value}
+}
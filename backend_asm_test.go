@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+type stubASMClient struct {
+	secretsmanageriface.SecretsManagerAPI
+	lister func(input *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error)
+	getter func(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+func (c *stubASMClient) ListSecretsWithContext(ctx aws.Context, input *secretsmanager.ListSecretsInput, opts ...request.Option) (*secretsmanager.ListSecretsOutput, error) {
+	return c.lister(input)
+}
+
+func (c *stubASMClient) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	return c.getter(input)
+}
+
+func Test_asmBackend_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		lister  func(input *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error)
+		getter  func(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+		ns      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "propagates list error",
+			lister: func(input *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+				return nil, fmt.Errorf("got an error")
+			},
+			ns:      "prod/app",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "pages, strips the namespace prefix, and expands JSON values",
+			lister: func(input *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+				if input.NextToken == nil {
+					return &secretsmanager.ListSecretsOutput{
+						NextToken: aws.String("2"),
+						SecretList: []*secretsmanager.SecretListEntry{
+							{ARN: aws.String("arn:one"), Name: aws.String("/prod/app/DB_CREDS")},
+						},
+					}, nil
+				}
+				return &secretsmanager.ListSecretsOutput{
+					NextToken: nil,
+					SecretList: []*secretsmanager.SecretListEntry{
+						{ARN: aws.String("arn:two"), Name: aws.String("/prod/app/API_KEY")},
+					},
+				}, nil
+			},
+			getter: func(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+				switch aws.StringValue(input.SecretId) {
+				case "arn:one":
+					return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"username":"app","password":"hunter2"}`)}, nil
+				case "arn:two":
+					return &secretsmanager.GetSecretValueOutput{SecretString: aws.String("shh")}, nil
+				default:
+					return nil, fmt.Errorf("unexpected secret id %q", aws.StringValue(input.SecretId))
+				}
+			},
+			ns: "prod/app",
+			want: map[string]string{
+				"username": "app",
+				"password": "hunter2",
+				"API_KEY":  "shh",
+			},
+		},
+		{
+			name: "propagates get error",
+			lister: func(input *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+				return &secretsmanager.ListSecretsOutput{
+					SecretList: []*secretsmanager.SecretListEntry{
+						{ARN: aws.String("arn:one"), Name: aws.String("/prod/app/DB_CREDS")},
+					},
+				}, nil
+			},
+			getter: func(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+				return nil, fmt.Errorf("got an error")
+			},
+			ns:      "prod/app",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &asmBackend{client: &stubASMClient{lister: tt.lister, getter: tt.getter}}
+			got, err := backend.List(context.Background(), tt.ns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_expandSecretValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  map[string]string
+	}{
+		{
+			name:  "plain string stays under key",
+			key:   "DB_PASSWORD",
+			value: "hunter2",
+			want:  map[string]string{"DB_PASSWORD": "hunter2"},
+		},
+		{
+			name:  "flat JSON object expands into its fields",
+			key:   "DB_CREDS",
+			value: `{"username":"app","password":"hunter2"}`,
+			want:  map[string]string{"username": "app", "password": "hunter2"},
+		},
+		{
+			name:  "non-object JSON stays under key",
+			key:   "COUNT",
+			value: "42",
+			want:  map[string]string{"COUNT": "42"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandSecretValue(tt.key, tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandSecretValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// azureKeyVaultBackend loads secrets from an Azure Key Vault.
+type azureKeyVaultBackend struct {
+	client *azsecrets.Client
+}
+
+func newAzureKeyVaultBackend() (Backend, error) {
+	vaultURL := os.Getenv("AZURE_VAULT_URL")
+	if vaultURL == "" {
+		return nil, fmt.Errorf("secretly: AZURE_VAULT_URL must be set to use the azkv backend")
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	client, err := azsecrets.NewClient(vaultURL, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &azureKeyVaultBackend{client: client}, nil
+}
+
+// List returns every secret in the vault whose name is prefixed with
+// namespace. Azure Key Vault has no concept of hierarchical paths, so the
+// namespace is matched against the secret name itself.
+func (b *azureKeyVaultBackend) List(ctx context.Context, namespace string) (map[string]string, error) {
+	namespace = strings.Trim(namespace, "/")
+	secrets := map[string]string{}
+
+	pager := b.client.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Value {
+			name := lastPathSegment(string(*item.ID))
+			key, ok := matchesNamespace(name, namespace)
+			if !ok {
+				continue
+			}
+			resp, err := b.client.GetSecret(ctx, name, "", nil)
+			if err != nil {
+				return nil, err
+			}
+			secrets[key] = *resp.Value
+		}
+	}
+	return secrets, nil
+}
+
+func lastPathSegment(path string) string {
+	parts := strings.Split(path, "/")
+	return parts[len(parts)-1]
+}
+
+// matchesNamespace reports whether a secret named name belongs to namespace,
+// and if so returns the key it should be stored under (name with the
+// namespace prefix and its separating "-" stripped). An empty namespace
+// matches every name. The namespace must be the whole leading segment of
+// name, not just a textual prefix: name "prod-app" matches namespace "prod",
+// but name "production-app" does not.
+func matchesNamespace(name, namespace string) (key string, ok bool) {
+	if namespace == "" {
+		return name, true
+	}
+	if name == namespace {
+		return "", true
+	}
+	if !strings.HasPrefix(name, namespace+"-") {
+		return "", false
+	}
+	return strings.TrimPrefix(name, namespace+"-"), true
+}
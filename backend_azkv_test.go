@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func Test_matchesNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		namespace string
+		wantKey   string
+		wantOK    bool
+	}{
+		{
+			name:      "empty namespace matches everything",
+			secret:    "prod-app-DB_PASSWORD",
+			namespace: "",
+			wantKey:   "prod-app-DB_PASSWORD",
+			wantOK:    true,
+		},
+		{
+			name:      "matching prefix strips namespace and separator",
+			secret:    "prod-app-DB_PASSWORD",
+			namespace: "prod-app",
+			wantKey:   "DB_PASSWORD",
+			wantOK:    true,
+		},
+		{
+			name:      "non-matching prefix is excluded",
+			secret:    "staging-app-DB_PASSWORD",
+			namespace: "prod-app",
+			wantKey:   "",
+			wantOK:    false,
+		},
+		{
+			name:      "textual prefix without a separator is excluded",
+			secret:    "production-api-KEY",
+			namespace: "prod",
+			wantKey:   "",
+			wantOK:    false,
+		},
+		{
+			name:      "exact match has no separator to strip",
+			secret:    "prod-app",
+			namespace: "prod-app",
+			wantKey:   "",
+			wantOK:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := matchesNamespace(tt.secret, tt.namespace)
+			if key != tt.wantKey || ok != tt.wantOK {
+				t.Errorf("matchesNamespace(%q, %q) = (%q, %v), want (%q, %v)", tt.secret, tt.namespace, key, ok, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
+
+func Test_lastPathSegment(t *testing.T) {
+	if got := lastPathSegment("https://myvault.vault.azure.net/secrets/prod-app-DB_PASSWORD"); got != "prod-app-DB_PASSWORD" {
+		t.Errorf("lastPathSegment() = %q, want prod-app-DB_PASSWORD", got)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// ssmBackend loads secrets from AWS Systems Manager Parameter Store. This
+// is secretly's original, and default, backend.
+type ssmBackend struct {
+	client ssmiface.SSMAPI
+}
+
+var (
+	_ Writer    = (*ssmBackend)(nil)
+	_ Deleter   = (*ssmBackend)(nil)
+	_ Historian = (*ssmBackend)(nil)
+)
+
+func newSSMBackend() (Backend, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ssmBackend{client: ssm.New(sess)}, nil
+}
+
+// List returns every parameter under namespace, keyed by the parameter
+// name with namespace's path stripped off.
+func (b *ssmBackend) List(ctx context.Context, namespace string) (map[string]string, error) {
+	path := "/" + strings.Trim(namespace, "/") + "/"
+	secrets := map[string]string{}
+
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		WithDecryption: aws.Bool(true),
+	}
+	for {
+		out, err := b.client.GetParametersByPathWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range out.Parameters {
+			key := strings.TrimPrefix(aws.StringValue(p.Name), path)
+			secrets[key] = aws.StringValue(p.Value)
+		}
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	return secrets, nil
+}
+
+// paramName builds the full SSM parameter name for a key under namespace.
+func paramName(namespace, key string) string {
+	return "/" + strings.Trim(namespace, "/") + "/" + key
+}
+
+// Write stores value as a SecureString parameter, overwriting any existing
+// value. If opts.KMSKeyID is set, it's passed through as the encryption key;
+// otherwise SSM encrypts with the account's default key.
+func (b *ssmBackend) Write(ctx context.Context, namespace, key, value string, opts WriteOptions) error {
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(paramName(namespace, key)),
+		Value:     aws.String(value),
+		Type:      aws.String(ssm.ParameterTypeSecureString),
+		Overwrite: aws.Bool(true),
+	}
+	if opts.KMSKeyID != "" {
+		input.KeyId = aws.String(opts.KMSKeyID)
+	}
+	_, err := b.client.PutParameterWithContext(ctx, input)
+	return err
+}
+
+// Delete removes the parameter for key under namespace.
+func (b *ssmBackend) Delete(ctx context.Context, namespace, key string) error {
+	_, err := b.client.DeleteParameterWithContext(ctx, &ssm.DeleteParameterInput{
+		Name: aws.String(paramName(namespace, key)),
+	})
+	return err
+}
+
+// History returns every past version of the parameter for key under
+// namespace, oldest first, as reported by SSM's parameter history.
+func (b *ssmBackend) History(ctx context.Context, namespace, key string) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	input := &ssm.GetParameterHistoryInput{
+		Name:           aws.String(paramName(namespace, key)),
+		WithDecryption: aws.Bool(true),
+	}
+	for {
+		out, err := b.client.GetParameterHistoryWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range out.Parameters {
+			entries = append(entries, HistoryEntry{
+				Version:      aws.Int64Value(p.Version),
+				ModifiedDate: aws.TimeValue(p.LastModifiedDate),
+				ModifiedUser: aws.StringValue(p.LastModifiedUser),
+				Value:        aws.StringValue(p.Value),
+			})
+		}
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	return entries, nil
+}
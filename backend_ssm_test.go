@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+type stubSSMClient struct {
+	ssmiface.SSMAPI
+	getter  func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error)
+	putter  func(input *ssm.PutParameterInput) (*ssm.PutParameterOutput, error)
+	deleter func(input *ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error)
+	history func(input *ssm.GetParameterHistoryInput) (*ssm.GetParameterHistoryOutput, error)
+}
+
+func (c *stubSSMClient) GetParametersByPathWithContext(ctx aws.Context, input *ssm.GetParametersByPathInput, opts ...request.Option) (*ssm.GetParametersByPathOutput, error) {
+	return c.getter(input)
+}
+
+func (c *stubSSMClient) PutParameterWithContext(ctx aws.Context, input *ssm.PutParameterInput, opts ...request.Option) (*ssm.PutParameterOutput, error) {
+	return c.putter(input)
+}
+
+func (c *stubSSMClient) DeleteParameterWithContext(ctx aws.Context, input *ssm.DeleteParameterInput, opts ...request.Option) (*ssm.DeleteParameterOutput, error) {
+	return c.deleter(input)
+}
+
+func (c *stubSSMClient) GetParameterHistoryWithContext(ctx aws.Context, input *ssm.GetParameterHistoryInput, opts ...request.Option) (*ssm.GetParameterHistoryOutput, error) {
+	return c.history(input)
+}
+
+func Test_ssmBackend_List(t *testing.T) {
+	tests := []struct {
+		name    string
+		getter  func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error)
+		ns      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "propagates error",
+			getter: func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+				return nil, fmt.Errorf("got an error")
+			},
+			ns:      "prefix",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "pages and strips the namespace prefix",
+			getter: func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+				if input.NextToken == nil {
+					return &ssm.GetParametersByPathOutput{
+						NextToken: aws.String("2"),
+						Parameters: []*ssm.Parameter{
+							{
+								Name:  aws.String("/prefix/ONE_VALUE"),
+								Value: aws.String("I AM THE FIRST VALUE"),
+							},
+						},
+					}, nil
+				}
+				return &ssm.GetParametersByPathOutput{
+					NextToken: nil,
+					Parameters: []*ssm.Parameter{
+						{
+							Name:  aws.String("/prefix/THIS_IS_A_TEST"),
+							Value: aws.String("I AM A VALUE"),
+						},
+					},
+				}, nil
+			},
+			ns: "prefix",
+			want: map[string]string{
+				"ONE_VALUE":      "I AM THE FIRST VALUE",
+				"THIS_IS_A_TEST": "I AM A VALUE",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &ssmBackend{client: &stubSSMClient{getter: tt.getter}}
+			got, err := backend.List(context.Background(), tt.ns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ssmBackend_Write(t *testing.T) {
+	var gotInput *ssm.PutParameterInput
+	backend := &ssmBackend{client: &stubSSMClient{
+		putter: func(input *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+			gotInput = input
+			return &ssm.PutParameterOutput{}, nil
+		},
+	}}
+
+	err := backend.Write(context.Background(), "prefix", "KEY", "value", WriteOptions{KMSKeyID: "alias/secretly"})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if aws.StringValue(gotInput.Name) != "/prefix/KEY" {
+		t.Errorf("Name = %v, want /prefix/KEY", aws.StringValue(gotInput.Name))
+	}
+	if aws.StringValue(gotInput.Type) != ssm.ParameterTypeSecureString {
+		t.Errorf("Type = %v, want %v", aws.StringValue(gotInput.Type), ssm.ParameterTypeSecureString)
+	}
+	if !aws.BoolValue(gotInput.Overwrite) {
+		t.Error("Overwrite = false, want true")
+	}
+	if aws.StringValue(gotInput.KeyId) != "alias/secretly" {
+		t.Errorf("KeyId = %v, want alias/secretly", aws.StringValue(gotInput.KeyId))
+	}
+}
+
+func Test_ssmBackend_History(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	backend := &ssmBackend{client: &stubSSMClient{
+		history: func(input *ssm.GetParameterHistoryInput) (*ssm.GetParameterHistoryOutput, error) {
+			return &ssm.GetParameterHistoryOutput{
+				Parameters: []*ssm.ParameterHistory{
+					{
+						Version:          aws.Int64(1),
+						LastModifiedDate: aws.Time(modified),
+						LastModifiedUser: aws.String("alice"),
+						Value:            aws.String("old"),
+					},
+				},
+			}, nil
+		},
+	}}
+
+	got, err := backend.History(context.Background(), "prefix", "KEY")
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	want := []HistoryEntry{
+		{Version: 1, ModifiedDate: modified, ModifiedUser: "alice", Value: "old"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %v, want %v", got, want)
+	}
+}
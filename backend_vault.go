@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// vaultLogical is the subset of *vaultapi.Logical that vaultBackend needs,
+// narrowed to an interface so tests can substitute a fake instead of
+// talking to a real Vault server.
+type vaultLogical interface {
+	ListWithContext(ctx context.Context, path string) (*vaultapi.Secret, error)
+	ReadWithContext(ctx context.Context, path string) (*vaultapi.Secret, error)
+}
+
+// vaultBackend loads secrets from a HashiCorp Vault KV secrets engine,
+// supporting both the v1 and v2 KV APIs.
+type vaultBackend struct {
+	logical vaultLogical
+}
+
+func newVaultBackend() (Backend, error) {
+	config := vaultapi.DefaultConfig()
+	if err := config.ReadEnvironment(); err != nil {
+		return nil, err
+	}
+	client, err := vaultapi.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleID, secretID := os.Getenv("VAULT_ROLE_ID"), os.Getenv("VAULT_SECRET_ID"); roleID != "" && secretID != "" {
+		secret, err := client.Logical().Write("auth/approle/login", map[string]interface{}{
+			"role_id":   roleID,
+			"secret_id": secretID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("secretly: vault approle login failed: %w", err)
+		}
+		client.SetToken(secret.Auth.ClientToken)
+	}
+
+	return &vaultBackend{logical: client.Logical()}, nil
+}
+
+// List returns every secret under namespace, trying the KV v2 layout first
+// and falling back to the KV v1 layout. KV v2 splits reads and lists
+// across two sub-paths under the mount (<mount>/data/<path> to read,
+// <mount>/metadata/<path> to list), so the two use different prefixes;
+// KV v1 uses namespace as-is for both.
+func (b *vaultBackend) List(ctx context.Context, namespace string) (map[string]string, error) {
+	namespace = strings.Trim(namespace, "/")
+
+	if secrets, err := b.listKV(ctx, withMetadataPrefix(namespace), withDataPrefix(namespace)); err == nil && secrets != nil {
+		return secrets, nil
+	}
+	return b.listKV(ctx, namespace, namespace)
+}
+
+// withDataPrefix rewrites a KV v2 path by inserting "data" after the mount,
+// e.g. "secret/prod/app" becomes "secret/data/prod/app". This is the
+// sub-path KV v2 reads a secret's value from.
+func withDataPrefix(namespace string) string {
+	return withSubPath(namespace, "data")
+}
+
+// withMetadataPrefix rewrites a KV v2 path by inserting "metadata" after
+// the mount, e.g. "secret/prod/app" becomes "secret/metadata/prod/app".
+// This is the sub-path KV v2 lists secret names from; "data" does not
+// support LIST.
+func withMetadataPrefix(namespace string) string {
+	return withSubPath(namespace, "metadata")
+}
+
+func withSubPath(namespace, subPath string) string {
+	parts := strings.SplitN(namespace, "/", 2)
+	if len(parts) != 2 {
+		return namespace
+	}
+	return parts[0] + "/" + subPath + "/" + parts[1]
+}
+
+// listKV lists secret names at listPath and reads each one from under
+// readPathPrefix, since KV v2 lists and reads from different sub-paths.
+func (b *vaultBackend) listKV(ctx context.Context, listPath, readPathPrefix string) (map[string]string, error) {
+	listResp, err := b.logical.ListWithContext(ctx, listPath)
+	if err != nil || listResp == nil || listResp.Data == nil {
+		return nil, err
+	}
+
+	keys, _ := listResp.Data["keys"].([]interface{})
+	secrets := map[string]string{}
+	for _, k := range keys {
+		name, _ := k.(string)
+		readResp, err := b.logical.ReadWithContext(ctx, readPathPrefix+"/"+name)
+		if err != nil {
+			return nil, err
+		}
+		if readResp == nil {
+			continue
+		}
+		data := readResp.Data
+		if nested, ok := data["data"].(map[string]interface{}); ok {
+			data = nested
+		}
+		for field, value := range data {
+			if s, ok := value.(string); ok {
+				secrets[strings.ToUpper(name)+"_"+field] = s
+			}
+		}
+	}
+	return secrets, nil
+}
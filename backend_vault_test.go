@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+type stubVaultLogical struct {
+	list func(path string) (*vaultapi.Secret, error)
+	read func(path string) (*vaultapi.Secret, error)
+}
+
+func (s *stubVaultLogical) ListWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	return s.list(path)
+}
+
+func (s *stubVaultLogical) ReadWithContext(ctx context.Context, path string) (*vaultapi.Secret, error) {
+	return s.read(path)
+}
+
+func Test_vaultBackend_List_kv2UsesMetadataToListAndDataToRead(t *testing.T) {
+	var listedPath, readPath string
+	logical := &stubVaultLogical{
+		list: func(path string) (*vaultapi.Secret, error) {
+			listedPath = path
+			return &vaultapi.Secret{Data: map[string]interface{}{
+				"keys": []interface{}{"db"},
+			}}, nil
+		},
+		read: func(path string) (*vaultapi.Secret, error) {
+			readPath = path
+			return &vaultapi.Secret{Data: map[string]interface{}{
+				"data": map[string]interface{}{"password": "hunter2"},
+			}}, nil
+		},
+	}
+
+	backend := &vaultBackend{logical: logical}
+	got, err := backend.List(context.Background(), "secret/prod/app")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if listedPath != "secret/metadata/prod/app" {
+		t.Errorf("listed path = %q, want secret/metadata/prod/app", listedPath)
+	}
+	if readPath != "secret/data/prod/app/db" {
+		t.Errorf("read path = %q, want secret/data/prod/app/db", readPath)
+	}
+
+	want := map[string]string{"DB_password": "hunter2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func Test_vaultBackend_List_fallsBackToKV1(t *testing.T) {
+	logical := &stubVaultLogical{
+		list: func(path string) (*vaultapi.Secret, error) {
+			// The KV v2 metadata list 404s (no secret, nil response) on a
+			// v1-only mount; the v1 attempt lists the path as-is instead.
+			if path == "secret/metadata/prod/app" {
+				return nil, nil
+			}
+			return &vaultapi.Secret{Data: map[string]interface{}{"keys": []interface{}{"db"}}}, nil
+		},
+		read: func(path string) (*vaultapi.Secret, error) {
+			if path != "secret/prod/app/db" {
+				t.Fatalf("unexpected read path %q", path)
+			}
+			return &vaultapi.Secret{Data: map[string]interface{}{"password": "hunter2"}}, nil
+		},
+	}
+
+	backend := &vaultBackend{logical: logical}
+	got, err := backend.List(context.Background(), "secret/prod/app")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	want := map[string]string{"DB_password": "hunter2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func Test_withDataPrefix(t *testing.T) {
+	if got := withDataPrefix("secret/prod/app"); got != "secret/data/prod/app" {
+		t.Errorf("withDataPrefix() = %q, want secret/data/prod/app", got)
+	}
+}
+
+func Test_withMetadataPrefix(t *testing.T) {
+	if got := withMetadataPrefix("secret/prod/app"); got != "secret/metadata/prod/app" {
+		t.Errorf("withMetadataPrefix() = %q, want secret/metadata/prod/app", got)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Writer is implemented by backends that support writing a secret value.
+// Not every Backend supports writing (e.g. a read-only aggregation
+// backend might not), so callers type-assert for it.
+type Writer interface {
+	Write(ctx context.Context, namespace, key, value string, opts WriteOptions) error
+}
+
+// WriteOptions carries the optional, backend-specific knobs for Write.
+type WriteOptions struct {
+	// KMSKeyID, if set, is the KMS key used to encrypt the value.
+	// Backends that always encrypt with an account default may ignore it.
+	KMSKeyID string
+}
+
+// Deleter is implemented by backends that support deleting a secret.
+type Deleter interface {
+	Delete(ctx context.Context, namespace, key string) error
+}
+
+// HistoryEntry is one past version of a secret, as returned by Historian.
+type HistoryEntry struct {
+	Version      int64
+	ModifiedDate time.Time
+	ModifiedUser string
+	Value        string
+}
+
+// Historian is implemented by backends that can report a secret's past
+// versions.
+type Historian interface {
+	History(ctx context.Context, namespace, key string) ([]HistoryEntry, error)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// runExec implements `secretly exec [flags] -- cmd args`: load secrets,
+// apply any {{backend:path}} substitutions, and run cmd with the resulting
+// environment. This is the tool's original, and still default-feeling,
+// mode of operation.
+func runExec(args []string) error {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	backendFlag := fs.String("backend", "", "secret backend to use: ssm, asm, vault, or azkv (default ssm, or $SECRETLY_BACKEND)")
+	namespace := fs.String("namespace", "", "comma-separated list of secret namespaces to load")
+	configPath := fs.String("config", "", "path to a secretly.yaml/secretly.json config (overrides --namespace/--backend when set)")
+	rewriteFiles := fs.String("rewrite-file", "", "comma-separated list of files to rewrite {{backend:path}} tokens in, in place")
+	failOnMissing := fs.Bool("fail-on-missing", false, "exit non-zero if any {{backend:path}} token cannot be resolved")
+	watch := fs.Duration("watch", 0, "if set, reload secrets on this interval and propagate any change to the child (see --restart-on-change)")
+	restartOnChange := fs.Bool("restart-on-change", false, "on a secret change, kill and re-exec the child instead of sending it SIGHUP")
+	restartSignal := fs.String("restart-signal", "SIGTERM", "signal used to stop the child before restarting it, when --restart-on-change is set")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return fmt.Errorf("exec: no command given to run")
+	}
+
+	name := backendName(*backendFlag)
+	secrets, err := loadSecretMap(name, *namespace, *configPath)
+	if err != nil {
+		return err
+	}
+	environ := addSecrets(os.Environ(), secrets)
+
+	files := splitCSV(*rewriteFiles)
+	lookups, err := buildLookups(environ, files)
+	if err != nil {
+		return err
+	}
+
+	environ, substErr := Substitute(environ, lookups)
+	for _, path := range files {
+		if err := SubstituteFile(path, lookups); err != nil && substErr == nil {
+			substErr = err
+		}
+	}
+	if substErr != nil {
+		if *failOnMissing {
+			return substErr
+		}
+		log.Printf("secretly: %v", substErr)
+	}
+
+	supervisor := newChildSupervisor(rest, *restartOnChange, signalByName(*restartSignal))
+	if err := supervisor.start(environ); err != nil {
+		return err
+	}
+
+	if *watch > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go watchLoop(*watch, func() map[string]string {
+			next, err := loadSecretMap(name, *namespace, *configPath)
+			if err != nil {
+				log.Printf("secretly: watch: %v", err)
+				return secrets
+			}
+			return next
+		}, func(old, next map[string]string) {
+			secrets = next
+			supervisor.onChange(addSecrets(os.Environ(), next))
+		}, stop)
+	}
+
+	return supervisor.wait()
+}
+
+// runRead implements `secretly read [flags] <key>`: print a single secret
+// from namespace in the chosen --format.
+func runRead(args []string) error {
+	fs := flag.NewFlagSet("read", flag.ExitOnError)
+	backendFlag := fs.String("backend", "", "secret backend to use: ssm, asm, vault, or azkv (default ssm, or $SECRETLY_BACKEND)")
+	namespace := fs.String("namespace", "", "namespace the key lives under, e.g. /prod/app")
+	format := fs.String("format", "env", "output format: env, json, dotenv, or tfvars")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		return fmt.Errorf("read: expected exactly one key argument")
+	}
+	key := rest[0]
+
+	backend, err := newBackend(backendName(*backendFlag))
+	if err != nil {
+		return err
+	}
+	secrets, err := findSecrets(backend, *namespace)
+	if err != nil {
+		return err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return fmt.Errorf("read: %s not found under namespace %q", key, *namespace)
+	}
+
+	out, err := formatSecrets(map[string]string{key: value}, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// runList implements `secretly list [flags]`: print every secret under the
+// given (comma-separated) namespaces in the chosen --format.
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	backendFlag := fs.String("backend", "", "secret backend to use: ssm, asm, vault, or azkv (default ssm, or $SECRETLY_BACKEND)")
+	namespace := fs.String("namespace", "", "comma-separated list of secret namespaces to load")
+	format := fs.String("format", "env", "output format: env, json, dotenv, or tfvars")
+	fs.Parse(args)
+
+	backend, err := newBackend(backendName(*backendFlag))
+	if err != nil {
+		return err
+	}
+
+	out, err := formatSecrets(mergeNamespaces(backend, *namespace), *format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// runWrite implements `secretly write [flags] <key> <value>`.
+func runWrite(args []string) error {
+	fs := flag.NewFlagSet("write", flag.ExitOnError)
+	backendFlag := fs.String("backend", "", "secret backend to use: ssm, asm, vault, or azkv (default ssm, or $SECRETLY_BACKEND)")
+	namespace := fs.String("namespace", "", "namespace to write the key under, e.g. /prod/app")
+	kmsKeyID := fs.String("kms-key-id", "", "KMS key ID to encrypt the value with (SSM backend only; defaults to the account's default SSM key)")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 2 {
+		return fmt.Errorf("write: expected a key and a value argument")
+	}
+	key, value := rest[0], rest[1]
+
+	name := backendName(*backendFlag)
+	backend, err := newBackend(name)
+	if err != nil {
+		return err
+	}
+	writer, ok := backend.(Writer)
+	if !ok {
+		return fmt.Errorf("write: backend %q does not support writing", name)
+	}
+	return writer.Write(context.Background(), *namespace, key, value, WriteOptions{KMSKeyID: *kmsKeyID})
+}
+
+// runDelete implements `secretly delete [flags] <key>`.
+func runDelete(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	backendFlag := fs.String("backend", "", "secret backend to use: ssm, asm, vault, or azkv (default ssm, or $SECRETLY_BACKEND)")
+	namespace := fs.String("namespace", "", "namespace the key lives under, e.g. /prod/app")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		return fmt.Errorf("delete: expected exactly one key argument")
+	}
+	key := rest[0]
+
+	name := backendName(*backendFlag)
+	backend, err := newBackend(name)
+	if err != nil {
+		return err
+	}
+	deleter, ok := backend.(Deleter)
+	if !ok {
+		return fmt.Errorf("delete: backend %q does not support deleting", name)
+	}
+	return deleter.Delete(context.Background(), *namespace, key)
+}
+
+// runHistory implements `secretly history [flags] <key>`, printing each
+// past version's number, modified date, and modifying user, one per line.
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	backendFlag := fs.String("backend", "", "secret backend to use: ssm, asm, vault, or azkv (default ssm, or $SECRETLY_BACKEND)")
+	namespace := fs.String("namespace", "", "namespace the key lives under, e.g. /prod/app")
+	showValues := fs.Bool("show-values", false, "include each historical value in the output")
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		return fmt.Errorf("history: expected exactly one key argument")
+	}
+	key := rest[0]
+
+	name := backendName(*backendFlag)
+	backend, err := newBackend(name)
+	if err != nil {
+		return err
+	}
+	historian, ok := backend.(Historian)
+	if !ok {
+		return fmt.Errorf("history: backend %q does not support history", name)
+	}
+	entries, err := historian.History(context.Background(), *namespace, key)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if *showValues {
+			fmt.Printf("%d\t%s\t%s\t%s\n", e.Version, e.ModifiedDate.Format(time.RFC3339), e.ModifiedUser, e.Value)
+		} else {
+			fmt.Printf("%d\t%s\t%s\n", e.Version, e.ModifiedDate.Format(time.RFC3339), e.ModifiedUser)
+		}
+	}
+	return nil
+}
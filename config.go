@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEntry declares one namespace-to-env-var mapping: which backend and
+// path to load secrets from, how to rename and prefix them on the way into
+// the environment, and which of the resulting names are required versus
+// optional.
+type ConfigEntry struct {
+	Backend string `json:"backend" yaml:"backend"`
+	Path    string `json:"path" yaml:"path"`
+	Prefix  string `json:"prefix" yaml:"prefix"`
+
+	// Rename maps a secret's leaf name, as returned by the backend, to a new
+	// name. Prefix is prepended after renaming, so the env var is merged in
+	// as Prefix plus the new name. Keys not listed here keep their original
+	// name.
+	Rename map[string]string `json:"rename" yaml:"rename"`
+
+	// Required and Optional name the (post-rename, post-prefix) env vars
+	// this entry is expected to produce. If either is non-empty, only the
+	// named vars are merged in and anything else the backend returned is
+	// dropped. A name missing from Required fails the load; a name
+	// missing from Optional is silently skipped.
+	Required []string `json:"required" yaml:"required"`
+	Optional []string `json:"optional" yaml:"optional"`
+}
+
+// Config is a secretly.yaml/secretly.json file: an ordered list of
+// entries, merged in the order they appear so that "last wins" is
+// explicit rather than implied by comma position in --namespace.
+type Config []ConfigEntry
+
+// loadConfig reads and parses the config file at path, selecting the YAML
+// or JSON decoder based on its extension.
+func loadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	var cfg Config
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	default:
+		return nil, fmt.Errorf("config: unsupported config file extension %q (want .yaml, .yml, or .json)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// applyConfig loads secrets for every entry in cfg and merges them into
+// environ, in declared order. A required name missing from any entry's
+// result is collected and reported as a single error instead of being
+// applied partially.
+func applyConfig(cfg Config, environ []string) ([]string, error) {
+	merged := map[string]string{}
+	var missing []string
+
+	for _, entry := range cfg {
+		backend, err := backendFactory(entry.Backend)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", entry.Path, err)
+		}
+		secrets, err := findSecrets(backend, entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %w", entry.Path, err)
+		}
+
+		renamed := map[string]string{}
+		for k, v := range secrets {
+			name := k
+			if r, ok := entry.Rename[k]; ok {
+				name = r
+			}
+			renamed[entry.Prefix+name] = v
+		}
+
+		filter := len(entry.Required) > 0 || len(entry.Optional) > 0
+		allowed := map[string]bool{}
+		for _, k := range entry.Required {
+			allowed[k] = true
+		}
+		for _, k := range entry.Optional {
+			allowed[k] = true
+		}
+
+		for k, v := range renamed {
+			if filter && !allowed[k] {
+				continue
+			}
+			merged[k] = v
+		}
+
+		for _, k := range entry.Required {
+			if _, ok := renamed[k]; !ok {
+				missing = append(missing, fmt.Sprintf("%s (from %s)", k, entry.Path))
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("config: missing required secrets: %s", strings.Join(missing, ", "))
+	}
+	return addSecrets(environ, merged), nil
+}
+
+// loadSecretMap loads the secrets for either a --config file or a plain
+// --backend/--namespace pair, whichever configPath selects. It's shared by
+// runExec's initial load and its --watch polling, so both see secrets the
+// same way.
+func loadSecretMap(backendKind, namespace, configPath string) (map[string]string, error) {
+	if configPath != "" {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+		environ, err := applyConfig(cfg, nil)
+		if err != nil {
+			return nil, err
+		}
+		return toMap(environ), nil
+	}
+
+	backend, err := newBackend(backendKind)
+	if err != nil {
+		return nil, err
+	}
+	return mergeNamespaces(backend, namespace), nil
+}
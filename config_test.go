@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func withFakeBackends(t *testing.T, backends map[string]Backend) {
+	t.Helper()
+	original := backendFactory
+	backendFactory = func(name string) (Backend, error) {
+		if b, ok := backends[name]; ok {
+			return b, nil
+		}
+		return original(name)
+	}
+	t.Cleanup(func() { backendFactory = original })
+}
+
+func Test_applyConfig(t *testing.T) {
+	prod := &mockClient{lister: func(ctx context.Context, ns string) (map[string]string, error) {
+		return map[string]string{
+			"DB_PWD":       "hunter2",
+			"FEATURE_FLAG": "on",
+			"UNLISTED":     "should be dropped",
+		}, nil
+	}}
+	withFakeBackends(t, map[string]Backend{"ssm": prod})
+
+	cfg := Config{
+		{
+			Backend:  "ssm",
+			Path:     "/prod/app/",
+			Rename:   map[string]string{"DB_PWD": "DATABASE_PASSWORD"},
+			Required: []string{"DATABASE_PASSWORD"},
+			Optional: []string{"FEATURE_FLAG"},
+		},
+	}
+
+	got, err := applyConfig(cfg, nil)
+	if err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	sort.Strings(got)
+	want := []string{
+		"DATABASE_PASSWORD=hunter2",
+		"FEATURE_FLAG=on",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyConfig() = %v, want %v", got, want)
+	}
+}
+
+func Test_applyConfig_missingRequired(t *testing.T) {
+	backend := &mockClient{lister: func(ctx context.Context, ns string) (map[string]string, error) {
+		return map[string]string{}, nil
+	}}
+	withFakeBackends(t, map[string]Backend{"ssm": backend})
+
+	cfg := Config{
+		{Backend: "ssm", Path: "/prod/app/", Required: []string{"DATABASE_PASSWORD"}},
+	}
+
+	if _, err := applyConfig(cfg, nil); err == nil {
+		t.Fatal("applyConfig() error = nil, want an error naming the missing required secret")
+	}
+}
+
+func Test_applyConfig_lastEntryWins(t *testing.T) {
+	first := &mockClient{lister: func(ctx context.Context, ns string) (map[string]string, error) {
+		return map[string]string{"SHARED": "from-first"}, nil
+	}}
+	second := &mockClient{lister: func(ctx context.Context, ns string) (map[string]string, error) {
+		return map[string]string{"SHARED": "from-second"}, nil
+	}}
+
+	i := 0
+	original := backendFactory
+	backendFactory = func(name string) (Backend, error) {
+		i++
+		if i == 1 {
+			return first, nil
+		}
+		return second, nil
+	}
+	t.Cleanup(func() { backendFactory = original })
+
+	cfg := Config{
+		{Backend: "ssm", Path: "/a/"},
+		{Backend: "ssm", Path: "/b/"},
+	}
+
+	got, err := applyConfig(cfg, nil)
+	if err != nil {
+		t.Fatalf("applyConfig() error = %v", err)
+	}
+	want := []string{"SHARED=from-second"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyConfig() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// formatSecrets renders secrets as text in one of the supported output
+// formats, with keys sorted for stable output.
+func formatSecrets(secrets map[string]string, format string) (string, error) {
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	switch format {
+	case "", "env":
+		var b strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s=%s\n", k, secrets[k])
+		}
+		return b.String(), nil
+	case "dotenv":
+		var b strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s=%q\n", k, secrets[k])
+		}
+		return b.String(), nil
+	case "tfvars":
+		var b strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s = %q\n", k, secrets[k])
+		}
+		return b.String(), nil
+	case "json":
+		out, err := json.MarshalIndent(secrets, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out) + "\n", nil
+	default:
+		return "", fmt.Errorf("unknown format %q (want env, json, dotenv, or tfvars)", format)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func Test_formatSecrets(t *testing.T) {
+	secrets := map[string]string{"B": "two", "A": "one"}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{name: "default is env", format: "", want: "A=one\nB=two\n"},
+		{name: "env", format: "env", want: "A=one\nB=two\n"},
+		{name: "dotenv", format: "dotenv", want: "A=\"one\"\nB=\"two\"\n"},
+		{name: "tfvars", format: "tfvars", want: "A = \"one\"\nB = \"two\"\n"},
+		{name: "json", format: "json", want: "{\n  \"A\": \"one\",\n  \"B\": \"two\"\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatSecrets(secrets, tt.format)
+			if err != nil {
+				t.Fatalf("formatSecrets() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatSecrets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatSecrets_unknownFormat(t *testing.T) {
+	if _, err := formatSecrets(map[string]string{}, "xml"); err == nil {
+		t.Error("formatSecrets() error = nil, want an error for an unknown format")
+	}
+}
@@ -0,0 +1,105 @@
+// Command secretly loads secrets from a pluggable backend (AWS SSM
+// Parameter Store by default) into the environment of a child process, and
+// offers read/write/delete/list/history subcommands over the same
+// backends.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("secretly: expected a subcommand: read, write, delete, list, history, or exec")
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "exec":
+		err = runExec(os.Args[2:])
+	case "read":
+		err = runRead(os.Args[2:])
+	case "list":
+		err = runList(os.Args[2:])
+	case "write":
+		err = runWrite(os.Args[2:])
+	case "delete":
+		err = runDelete(os.Args[2:])
+	case "history":
+		err = runHistory(os.Args[2:])
+	default:
+		log.Fatalf("secretly: unknown subcommand %q (want read, write, delete, list, history, or exec)", os.Args[1])
+	}
+	if err != nil {
+		log.Fatalf("secretly: %v", err)
+	}
+}
+
+// toMap converts a KEY=VALUE environ slice into a map. Only the first "="
+// is treated as the key/value separator, so values may themselves contain
+// "=".
+func toMap(environ []string) map[string]string {
+	m := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		m[parts[0]] = parts[1]
+	}
+	return m
+}
+
+// addSecrets merges secrets into environ, returning one KEY=VALUE entry per
+// key of either, with secrets' values winning for keys that appear in both.
+// The order of the returned entries is not defined and does not follow
+// environ's order.
+func addSecrets(environ []string, secrets map[string]string) []string {
+	m := toMap(environ)
+	for k, v := range secrets {
+		m[k] = v
+	}
+	out := make([]string, 0, len(m))
+	for k, v := range m {
+		out = append(out, fmt.Sprintf("%s=%s", k, v))
+	}
+	return out
+}
+
+// findSecrets loads every secret under ns from backend.
+func findSecrets(backend Backend, ns string) (map[string]string, error) {
+	return backend.List(context.Background(), ns)
+}
+
+// mergeNamespaces loads secrets for each comma-separated prefix in ns from
+// backend and merges them into a single map. Prefixes are merged in the
+// order given, so when the same key appears under more than one prefix the
+// later prefix wins.
+func mergeNamespaces(backend Backend, ns string) map[string]string {
+	merged := map[string]string{}
+	for _, prefix := range strings.Split(ns, ",") {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		secrets, err := findSecrets(backend, prefix)
+		if err != nil {
+			log.Printf("secretly: failed to load secrets from %q: %v", prefix, err)
+			continue
+		}
+		for k, v := range secrets {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// findAllSecrets loads secrets for each comma-separated prefix in ns from
+// backend and merges the results into environ.
+func findAllSecrets(backend Backend, ns string, environ []string) []string {
+	return addSecrets(environ, mergeNamespaces(backend, ns))
+}
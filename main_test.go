@@ -1,14 +1,11 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
-
-	"fmt"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 )
 
 func Test_addSecrets(t *testing.T) {
@@ -78,9 +75,20 @@ func Test_addSecrets(t *testing.T) {
 	}
 }
 
+// mockClient is a Backend whose List is driven directly by a test's
+// lister func, so backend-specific pagination and path handling don't need
+// to be re-tested at this layer.
+type mockClient struct {
+	lister func(ctx context.Context, ns string) (map[string]string, error)
+}
+
+func (c *mockClient) List(ctx context.Context, ns string) (map[string]string, error) {
+	return c.lister(ctx, ns)
+}
+
 func Test_findSecrets(t *testing.T) {
 	type args struct {
-		getter func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error)
+		lister func(ctx context.Context, ns string) (map[string]string, error)
 		ns     string
 	}
 	tests := []struct {
@@ -92,7 +100,7 @@ func Test_findSecrets(t *testing.T) {
 		{
 			name: "propagates error",
 			args: args{
-				getter: func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+				lister: func(ctx context.Context, ns string) (map[string]string, error) {
 					return nil, fmt.Errorf("got an error")
 				},
 				ns: "prefix",
@@ -101,28 +109,12 @@ func Test_findSecrets(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "concats",
+			name: "returns backend secrets",
 			args: args{
-				getter: func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
-					if input.NextToken == nil {
-						return &ssm.GetParametersByPathOutput{
-							NextToken: aws.String("2"),
-							Parameters: []*ssm.Parameter{
-								{
-									Name:  aws.String("/prefix/ONE_VALUE"),
-									Value: aws.String("I AM THE FIRST VALUE"),
-								},
-							},
-						}, nil
-					}
-					return &ssm.GetParametersByPathOutput{
-						NextToken: nil,
-						Parameters: []*ssm.Parameter{
-							{
-								Name:  aws.String("/prefix/THIS_IS_A_TEST"),
-								Value: aws.String("I AM A VALUE"),
-							},
-						},
+				lister: func(ctx context.Context, ns string) (map[string]string, error) {
+					return map[string]string{
+						"ONE_VALUE":      "I AM THE FIRST VALUE",
+						"THIS_IS_A_TEST": "I AM A VALUE",
 					}, nil
 				},
 				ns: "prefix",
@@ -136,8 +128,8 @@ func Test_findSecrets(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := &mockClient{getter: tt.args.getter}
-			got, err := findSecrets(client, tt.args.ns)
+			backend := &mockClient{lister: tt.args.lister}
+			got, err := findSecrets(backend, tt.args.ns)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("findSecrets() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -151,102 +143,60 @@ func Test_findSecrets(t *testing.T) {
 
 func Test_findAllSecrets(t *testing.T) {
 	type args struct {
-		getter func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error)
+		lister func(ctx context.Context, ns string) (map[string]string, error)
 		ns     string
 	}
-	sharedGetter := func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
-		if *input.Path == "/prefix/1/" {
-			if input.NextToken == nil {
-				return &ssm.GetParametersByPathOutput{
-					NextToken: aws.String("2"),
-					Parameters: []*ssm.Parameter{
-						{
-							Name:  aws.String("/prefix/1/VALUE_FIRST_ONLY"),
-							Value: aws.String("I CAME FROM PREFIX 1"),
-						},
-					},
-				}, nil
-			} else {
-				return &ssm.GetParametersByPathOutput{
-					NextToken: nil,
-					Parameters: []*ssm.Parameter{
-						{
-							Name:  aws.String("/prefix/1/VALUE_IN_BOTH"),
-							Value: aws.String("I CAME FROM PREFIX 1"),
-						},
-					},
-				}, nil
-			}
+	sharedLister := func(ctx context.Context, ns string) (map[string]string, error) {
+		switch ns {
+		case "prefix/1":
+			return map[string]string{
+				"VALUE_FIRST_ONLY": "I CAME FROM PREFIX 1",
+				"VALUE_IN_BOTH":    "I CAME FROM PREFIX 1",
+			}, nil
+		case "prefix/2":
+			return map[string]string{
+				"VALUE_SECOND_ONLY": "I CAME FROM PREFIX 2",
+				"VALUE_IN_BOTH":     "I CAME FROM PREFIX 2",
+			}, nil
 		}
-		if *input.Path == "/prefix/2/" {
-			if input.NextToken == nil {
-				return &ssm.GetParametersByPathOutput{
-					NextToken: aws.String("2"),
-					Parameters: []*ssm.Parameter{
-						{
-							Name:  aws.String("/prefix/2/VALUE_SECOND_ONLY"),
-							Value: aws.String("I CAME FROM PREFIX 2"),
-						},
-					},
-				}, nil
-			} else {
-				return &ssm.GetParametersByPathOutput{
-					NextToken: nil,
-					Parameters: []*ssm.Parameter{
-						{
-							Name:  aws.String("/prefix/2/VALUE_IN_BOTH"),
-							Value: aws.String("I CAME FROM PREFIX 2"),
-						},
-					},
-				}, nil
-			}
-		}
-		// these are returned when empty prefix is sent.
-		return &ssm.GetParametersByPathOutput{
-			NextToken: nil,
-			Parameters: []*ssm.Parameter{
-			},
-		}, nil
+		return map[string]string{}, nil
 	}
 
 	tests := []struct {
-		name    string
-		args    args
-		want    []string
-		wantErr bool
+		name string
+		args args
+		want []string
 	}{
 		{
 			name: "basicFindAllTest",
 			args: args{
-				getter: sharedGetter,
-				ns: "prefix/1,,,,,prefix/2",
+				lister: sharedLister,
+				ns:     "prefix/1,,,,,prefix/2",
 			},
-			want: []string {
+			want: []string{
 				"VALUE_FIRST_ONLY=I CAME FROM PREFIX 1",
 				"VALUE_IN_BOTH=I CAME FROM PREFIX 2",
 				"VALUE_SECOND_ONLY=I CAME FROM PREFIX 2",
 			},
-			wantErr: false,
 		},
 		{
 			name: "ReversedPrefixOrderFindAllTest",
 			args: args{
-				getter: sharedGetter,
-				ns: "prefix/2,prefix/1",
+				lister: sharedLister,
+				ns:     "prefix/2,prefix/1",
 			},
-			want: []string {
+			want: []string{
 				"VALUE_FIRST_ONLY=I CAME FROM PREFIX 1",
 				"VALUE_IN_BOTH=I CAME FROM PREFIX 1",
 				"VALUE_SECOND_ONLY=I CAME FROM PREFIX 2",
 			},
-			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := &mockClient{getter: tt.args.getter}
+			backend := &mockClient{lister: tt.args.lister}
 			var environ []string
-			got := findAllSecrets(client, tt.args.ns, environ)
+			got := findAllSecrets(backend, tt.args.ns, environ)
 
 			sort.Strings(got)
 
@@ -257,15 +207,6 @@ func Test_findAllSecrets(t *testing.T) {
 	}
 }
 
-type mockClient struct {
-	ssmiface.SSMAPI
-	getter func(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error)
-}
-
-func (c *mockClient) GetParametersByPath(input *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
-	return c.getter(input)
-}
-
 func Test_toMap(t *testing.T) {
 	type args struct {
 		environ []string
@@ -295,3 +236,24 @@ func Test_toMap(t *testing.T) {
 		})
 	}
 }
+
+func Test_backendName(t *testing.T) {
+	tests := []struct {
+		name      string
+		flagValue string
+		env       string
+		want      string
+	}{
+		{name: "flag wins", flagValue: "vault", env: "ssm", want: "vault"},
+		{name: "falls back to env", flagValue: "", env: "asm", want: "asm"},
+		{name: "defaults to ssm", flagValue: "", env: "", want: "ssm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SECRETLY_BACKEND", tt.env)
+			if got := backendName(tt.flagValue); got != tt.want {
+				t.Errorf("backendName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
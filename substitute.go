@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Substitute scans each element of environ for {{backend:path}} tokens
+// (e.g. {{ssm:/prod/app/DB_PASSWORD}}, {{vault:secret/app/db}}) and
+// replaces each one inline with the secret resolved from lookups[backend],
+// leaving the surrounding text alone. This coexists with addSecrets: where
+// addSecrets replaces a whole env var by key match, Substitute lets a
+// secret be embedded inside a larger value such as a URL or connection
+// string.
+//
+// Each distinct token is resolved at most once, even if it appears many
+// times across environ, so repeated tokens cost one backend round-trip.
+// A token escaped as \{{backend:path}} is left as the literal text
+// {{backend:path}} instead of being resolved. If any token cannot be
+// resolved, Substitute still returns the best-effort result (with those
+// tokens left in place) alongside a non-nil error listing them; it is up
+// to the caller (see the --fail-on-missing flag) to decide whether that
+// error is fatal.
+func Substitute(environ []string, lookups map[string]Backend) ([]string, error) {
+	cache := map[string]map[string]string{}
+	var missing []string
+
+	out := make([]string, len(environ))
+	for i, kv := range environ {
+		out[i] = substituteValue(kv, lookups, cache, &missing)
+	}
+	return out, missingErr(missing)
+}
+
+// SubstituteFile rewrites the file at path in place, resolving every
+// {{backend:path}} token in its contents the same way Substitute does.
+func SubstituteFile(path string, lookups map[string]Backend) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	cache := map[string]map[string]string{}
+	var missing []string
+	rewritten := substituteValue(string(contents), lookups, cache, &missing)
+
+	if err := os.WriteFile(path, []byte(rewritten), info.Mode()); err != nil {
+		return err
+	}
+	if err := missingErr(missing); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// substituteValue replaces every {{backend:path}} token in s, recording the
+// token text (without braces) in *missing whenever it can't be resolved.
+func substituteValue(s string, lookups map[string]Backend, cache map[string]map[string]string, missing *[]string) string {
+	var b strings.Builder
+	i := 0
+	for i < len(s) {
+		if strings.HasPrefix(s[i:], `\{{`) {
+			rest := s[i+1:]
+			end := strings.Index(rest, "}}")
+			if end == -1 {
+				b.WriteString(rest)
+				break
+			}
+			b.WriteString(rest[:end+2])
+			i += 1 + end + 2
+			continue
+		}
+		if strings.HasPrefix(s[i:], "{{") {
+			end := strings.Index(s[i+2:], "}}")
+			if end == -1 {
+				b.WriteString(s[i:])
+				break
+			}
+			token := s[i+2 : i+2+end]
+			i += 2 + end + 2
+
+			backendKind, path, ok := strings.Cut(token, ":")
+			if !ok {
+				b.WriteString("{{" + token + "}}")
+				continue
+			}
+			value, resolved := resolveToken(backendKind, path, lookups, cache)
+			if !resolved {
+				*missing = append(*missing, token)
+				b.WriteString("{{" + token + "}}")
+				continue
+			}
+			b.WriteString(value)
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// resolveToken looks up the secret named by path under backendKind. The
+// cache is keyed by namespace (not by the full token), since List already
+// returns every key under a namespace in one round trip: resolving several
+// distinct leaf keys under the same namespace costs one backend.List call,
+// not one per key.
+func resolveToken(backendKind, path string, lookups map[string]Backend, cache map[string]map[string]string) (string, bool) {
+	namespace, key := splitPath(path)
+	cacheKey := backendKind + ":" + namespace
+
+	secrets, ok := cache[cacheKey]
+	if !ok {
+		backend, ok := lookups[backendKind]
+		if !ok {
+			return "", false
+		}
+
+		var err error
+		secrets, err = backend.List(context.Background(), namespace)
+		if err != nil {
+			return "", false
+		}
+		cache[cacheKey] = secrets
+	}
+
+	value, ok := secrets[key]
+	return value, ok
+}
+
+// splitPath splits a token path like "/prod/app/DB_PASSWORD" into the
+// namespace to List ("/prod/app") and the leaf key to pull out of the
+// result ("DB_PASSWORD").
+func splitPath(path string) (namespace, key string) {
+	path = strings.TrimSuffix(path, "/")
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 {
+		return "", path
+	}
+	return path[:idx], path[idx+1:]
+}
+
+// splitCSV splits a comma-separated flag value into its trimmed,
+// non-empty parts.
+func splitCSV(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// buildLookups returns the lookups map Substitute and SubstituteFile need,
+// constructing a Backend for every distinct backend kind referenced by a
+// {{kind:path}} token across environ and the given files.
+func buildLookups(environ []string, files []string) (map[string]Backend, error) {
+	kinds := map[string]bool{}
+	for _, kv := range environ {
+		collectTokenKinds(kv, kinds)
+	}
+	for _, path := range files {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		collectTokenKinds(string(contents), kinds)
+	}
+
+	lookups := map[string]Backend{}
+	for k := range kinds {
+		b, err := newBackend(k)
+		if err != nil {
+			return nil, err
+		}
+		lookups[k] = b
+	}
+	return lookups, nil
+}
+
+// collectTokenKinds adds the backend kind of every {{kind:path}} token
+// found in s to kinds. Escaped \{{kind:path}} text is not a token and is
+// skipped.
+func collectTokenKinds(s string, kinds map[string]bool) {
+	for {
+		start := strings.Index(s, "{{")
+		if start == -1 {
+			return
+		}
+		end := strings.Index(s[start+2:], "}}")
+		if end == -1 {
+			return
+		}
+		token := s[start+2 : start+2+end]
+		escaped := start > 0 && s[start-1] == '\\'
+		if kind, _, ok := strings.Cut(token, ":"); ok && !escaped {
+			kinds[kind] = true
+		}
+		s = s[start+2+end+2:]
+	}
+}
+
+func missingErr(missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	unique := make([]string, 0, len(missing))
+	for _, m := range missing {
+		if !seen[m] {
+			seen[m] = true
+			unique = append(unique, m)
+		}
+	}
+	sort.Strings(unique)
+	return fmt.Errorf("secretly: unresolved tokens: %s", strings.Join(unique, ", "))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func Test_Substitute(t *testing.T) {
+	calls := 0
+	ssm := &mockClient{lister: func(ctx context.Context, ns string) (map[string]string, error) {
+		calls++
+		if ns == "/prod/app" {
+			return map[string]string{"DB_PASSWORD": "hunter2"}, nil
+		}
+		return nil, nil
+	}}
+	lookups := map[string]Backend{"ssm": ssm}
+
+	environ := []string{
+		"DATABASE_URL=postgres://app:{{ssm:/prod/app/DB_PASSWORD}}@db/app",
+		"DATABASE_URL_AGAIN=postgres://app:{{ssm:/prod/app/DB_PASSWORD}}@db/app2",
+		"LITERAL=\\{{not:a-token}}",
+	}
+
+	got, err := Substitute(environ, lookups)
+	if err != nil {
+		t.Fatalf("Substitute() error = %v", err)
+	}
+
+	want := []string{
+		"DATABASE_URL=postgres://app:hunter2@db/app",
+		"DATABASE_URL_AGAIN=postgres://app:hunter2@db/app2",
+		"LITERAL={{not:a-token}}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Substitute() = %v, want %v", got, want)
+	}
+	if calls != 1 {
+		t.Errorf("backend was listed %d times, want 1 (token should be cached)", calls)
+	}
+}
+
+func Test_Substitute_sharesOneListCallAcrossDistinctKeysInANamespace(t *testing.T) {
+	calls := 0
+	ssm := &mockClient{lister: func(ctx context.Context, ns string) (map[string]string, error) {
+		calls++
+		if ns == "/prod/app" {
+			return map[string]string{"DB_PASSWORD": "hunter2", "API_KEY": "shh"}, nil
+		}
+		return nil, nil
+	}}
+	lookups := map[string]Backend{"ssm": ssm}
+
+	environ := []string{
+		"DATABASE_URL=postgres://app:{{ssm:/prod/app/DB_PASSWORD}}@db/app",
+		"API_KEY={{ssm:/prod/app/API_KEY}}",
+	}
+
+	got, err := Substitute(environ, lookups)
+	if err != nil {
+		t.Fatalf("Substitute() error = %v", err)
+	}
+
+	want := []string{
+		"DATABASE_URL=postgres://app:hunter2@db/app",
+		"API_KEY=shh",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Substitute() = %v, want %v", got, want)
+	}
+	if calls != 1 {
+		t.Errorf("backend was listed %d times, want 1 (distinct keys under one namespace should share a List call)", calls)
+	}
+}
+
+func Test_Substitute_missingToken(t *testing.T) {
+	lookups := map[string]Backend{
+		"ssm": &mockClient{lister: func(ctx context.Context, ns string) (map[string]string, error) {
+			return map[string]string{}, nil
+		}},
+	}
+
+	got, err := Substitute([]string{"FOO={{ssm:/prod/app/MISSING}}"}, lookups)
+	if err == nil {
+		t.Fatal("Substitute() error = nil, want an error listing the unresolved token")
+	}
+	want := []string{"FOO={{ssm:/prod/app/MISSING}}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Substitute() = %v, want %v", got, want)
+	}
+}
+
+func Test_splitPath(t *testing.T) {
+	tests := []struct {
+		path          string
+		wantNamespace string
+		wantKey       string
+	}{
+		{"/prod/app/DB_PASSWORD", "/prod/app", "DB_PASSWORD"},
+		{"DB_PASSWORD", "", "DB_PASSWORD"},
+	}
+	for _, tt := range tests {
+		ns, key := splitPath(tt.path)
+		if ns != tt.wantNamespace || key != tt.wantKey {
+			t.Errorf("splitPath(%q) = (%q, %q), want (%q, %q)", tt.path, ns, key, tt.wantNamespace, tt.wantKey)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// OnChange is invoked whenever a --watch poll finds that the secrets
+// loaded for the running child differ from the last poll. Taking the old
+// and new maps (rather than just the new one) lets callers log or test
+// exactly what changed.
+type OnChange func(old, new map[string]string)
+
+// watchLoop polls loadSecrets every interval and calls onChange whenever
+// the result differs from the previous poll. It blocks until stop is
+// closed, so callers should run it in its own goroutine.
+func watchLoop(interval time.Duration, loadSecrets func() map[string]string, onChange OnChange, stop <-chan struct{}) {
+	last := loadSecrets()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			next := loadSecrets()
+			if !secretsEqual(last, next) {
+				onChange(last, next)
+				last = next
+			}
+		}
+	}
+}
+
+func secretsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// childSupervisor runs command as a child process and, on request, either
+// signals it in place or kills and restarts it with a fresh environment.
+// This is what turns --watch from "notice a rotation happened" into
+// "actually get the new credentials to the child".
+type childSupervisor struct {
+	command         []string
+	restartOnChange bool
+	restartSignal   syscall.Signal
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	childDone chan struct{}
+	exitErr   error
+	done      chan struct{}
+}
+
+func newChildSupervisor(command []string, restartOnChange bool, restartSignal syscall.Signal) *childSupervisor {
+	return &childSupervisor{
+		command:         command,
+		restartOnChange: restartOnChange,
+		restartSignal:   restartSignal,
+		done:            make(chan struct{}),
+	}
+}
+
+// start launches the child with environ and begins waiting on it in the
+// background. It may be called again after a restart-triggered exit.
+func (s *childSupervisor) start(environ []string) error {
+	cmd := exec.Command(s.command[0], s.command[1:]...)
+	cmd.Env = environ
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	childDone := make(chan struct{})
+	s.mu.Lock()
+	s.cmd = cmd
+	s.childDone = childDone
+	s.mu.Unlock()
+
+	go func() {
+		// cmd.Wait() must only ever be called here, once per cmd: onChange
+		// waits on childDone instead of calling cmd.Wait() itself, so two
+		// goroutines never race to reap the same child.
+		err := cmd.Wait()
+		close(childDone)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.cmd != cmd {
+			// A restart already replaced this child; this exit was
+			// expected, not the final one.
+			return
+		}
+		s.exitErr = err
+		close(s.done)
+	}()
+	return nil
+}
+
+// onChange reacts to a secret change: with restartOnChange it kills the
+// child with restartSignal and re-execs it with environ; otherwise it
+// sends SIGHUP so the child can reload in place.
+func (s *childSupervisor) onChange(environ []string) {
+	s.mu.Lock()
+	cmd := s.cmd
+	childDone := s.childDone
+	if s.restartOnChange {
+		// Detach cmd before signalling it, so start()'s goroutine sees that
+		// its exit was caused by this restart and not the final one.
+		s.cmd = nil
+	}
+	s.mu.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+
+	if !s.restartOnChange {
+		if err := cmd.Process.Signal(syscall.SIGHUP); err != nil {
+			log.Printf("secretly: watch: failed to send SIGHUP: %v", err)
+		}
+		return
+	}
+
+	if err := cmd.Process.Signal(s.restartSignal); err != nil {
+		log.Printf("secretly: watch: failed to signal child for restart: %v", err)
+	}
+	// Wait for start()'s goroutine to reap the child rather than calling
+	// cmd.Wait() here ourselves, since cmd.Wait() must only be called once.
+	<-childDone
+
+	if err := s.start(environ); err != nil {
+		log.Printf("secretly: watch: failed to restart child: %v", err)
+		// No child is left running, so this is the final exit.
+		s.mu.Lock()
+		select {
+		case <-s.done:
+		default:
+			s.exitErr = err
+			close(s.done)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// wait blocks until the child's final exit (i.e. not one caused by a
+// restart) and translates its exit code the same way a plain exec.Cmd
+// run would.
+func (s *childSupervisor) wait() error {
+	<-s.done
+	if s.exitErr != nil {
+		if exitErr, ok := s.exitErr.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		return s.exitErr
+	}
+	return nil
+}
+
+// signalByName maps a signal name such as "SIGTERM" or "TERM" to its
+// syscall.Signal, defaulting to SIGTERM for anything unrecognized.
+func signalByName(name string) syscall.Signal {
+	switch strings.ToUpper(strings.TrimPrefix(name, "SIG")) {
+	case "HUP":
+		return syscall.SIGHUP
+	case "INT":
+		return syscall.SIGINT
+	case "QUIT":
+		return syscall.SIGQUIT
+	case "KILL":
+		return syscall.SIGKILL
+	case "USR1":
+		return syscall.SIGUSR1
+	case "USR2":
+		return syscall.SIGUSR2
+	default:
+		return syscall.SIGTERM
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestMain lets watch_test.go re-exec the test binary itself as the child
+// process childSupervisor supervises, the standard way to unit-test code
+// that manages a real subprocess without depending on an external binary.
+// When GO_WANT_HELPER_PROCESS is set, the binary runs as that child instead
+// of as the test suite.
+func TestMain(m *testing.M) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		runHelperProcess()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runHelperProcess is the child childSupervisor tests launch: it appends
+// HELPER_MARKER to the file named by HELPER_FILE once on startup, then again
+// (suffixed "-sighup") the first time it receives SIGHUP, and otherwise
+// blocks until killed so the test controls its lifetime entirely through
+// signals.
+func runHelperProcess() {
+	file := os.Getenv("HELPER_FILE")
+	marker := os.Getenv("HELPER_MARKER")
+	appendLine(file, marker)
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	<-sighup
+	appendLine(file, marker+"-sighup")
+
+	select {}
+}
+
+func appendLine(file, line string) {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		os.Exit(1)
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// waitForLine polls file until one of its lines equals want, failing the
+// test if that doesn't happen within a second.
+func waitForLine(t *testing.T, file, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		contents, err := os.ReadFile(file)
+		if err == nil {
+			for _, line := range strings.Split(string(contents), "\n") {
+				if line == want {
+					return
+				}
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("file %s never contained line %q", file, want)
+}
+
+func helperEnviron(file, marker string) []string {
+	return append(os.Environ(),
+		"GO_WANT_HELPER_PROCESS=1",
+		"HELPER_FILE="+file,
+		"HELPER_MARKER="+marker,
+	)
+}
+
+func Test_watchLoop_detectsChange(t *testing.T) {
+	polls := 0
+	loadSecrets := func() map[string]string {
+		polls++
+		if polls == 1 {
+			return map[string]string{"PASSWORD": "old"}
+		}
+		return map[string]string{"PASSWORD": "new"}
+	}
+
+	type change struct{ old, new map[string]string }
+	changes := make(chan change, 1)
+	onChange := func(old, new map[string]string) {
+		changes <- change{old, new}
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go watchLoop(5*time.Millisecond, loadSecrets, onChange, stop)
+
+	select {
+	case c := <-changes:
+		want := map[string]string{"PASSWORD": "old"}
+		if !reflect.DeepEqual(c.old, want) {
+			t.Errorf("onChange old = %v, want %v", c.old, want)
+		}
+		want = map[string]string{"PASSWORD": "new"}
+		if !reflect.DeepEqual(c.new, want) {
+			t.Errorf("onChange new = %v, want %v", c.new, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watchLoop did not call onChange within 1s")
+	}
+}
+
+func Test_watchLoop_noChangeNoCallback(t *testing.T) {
+	loadSecrets := func() map[string]string {
+		return map[string]string{"PASSWORD": "same"}
+	}
+	onChange := func(old, new map[string]string) {
+		t.Fatal("onChange called despite secrets being unchanged")
+	}
+
+	stop := make(chan struct{})
+	go watchLoop(2*time.Millisecond, loadSecrets, onChange, stop)
+	time.Sleep(20 * time.Millisecond)
+	close(stop)
+}
+
+func Test_secretsEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]string
+		want bool
+	}{
+		{"both empty", map[string]string{}, map[string]string{}, true},
+		{"equal", map[string]string{"A": "1"}, map[string]string{"A": "1"}, true},
+		{"different value", map[string]string{"A": "1"}, map[string]string{"A": "2"}, false},
+		{"different size", map[string]string{"A": "1"}, map[string]string{"A": "1", "B": "2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := secretsEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("secretsEqual(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_childSupervisor_onChange_restartsChildWithNewEnviron(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out")
+
+	s := newChildSupervisor([]string{os.Args[0]}, true, syscall.SIGTERM)
+	if err := s.start(helperEnviron(file, "first")); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	defer s.cmd.Process.Kill()
+
+	waitForLine(t, file, "first")
+
+	s.onChange(helperEnviron(file, "second"))
+
+	waitForLine(t, file, "second")
+}
+
+func Test_childSupervisor_onChange_sendsSIGHUPWithoutRestart(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out")
+	environ := helperEnviron(file, "only")
+
+	s := newChildSupervisor([]string{os.Args[0]}, false, syscall.SIGTERM)
+	if err := s.start(environ); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	defer s.cmd.Process.Kill()
+
+	waitForLine(t, file, "only")
+	firstChild := s.cmd
+
+	s.onChange(environ)
+
+	waitForLine(t, file, "only-sighup")
+	if s.cmd != firstChild {
+		t.Error("onChange() restarted the child when restartOnChange was false")
+	}
+}
+
+func Test_signalByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"SIGTERM", "terminated"},
+		{"TERM", "terminated"},
+		{"SIGHUP", "hangup"},
+		{"unknown", "terminated"},
+	}
+	for _, tt := range tests {
+		if got := signalByName(tt.name).String(); got != tt.want {
+			t.Errorf("signalByName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}